@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xanderstrike/goplaxt/lib/store"
+)
+
+// tokenRefreshAfterHours mirrors the threshold in api() where we proactively
+// refresh a user's Trakt token.
+const tokenRefreshAfterHours = 1440
+
+func runUsersCommand(args []string) {
+	if len(args) < 1 {
+		usersUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		usersList()
+	case "show":
+		requireUser(args, usersShow)
+	case "delete":
+		requireUser(args, usersDelete)
+	case "refresh":
+		requireUser(args, usersRefresh)
+	default:
+		usersUsage()
+		os.Exit(1)
+	}
+}
+
+func requireUser(args []string, fn func(idOrUsername string)) {
+	if len(args) < 2 {
+		usersUsage()
+		os.Exit(1)
+	}
+	fn(args[1])
+}
+
+func usersUsage() {
+	fmt.Println("Usage: goplaxt users <list|show|delete|refresh> [id|username]")
+}
+
+func resolveUser(idOrUsername string) *store.User {
+	if user := storage.GetUser(idOrUsername); user != nil {
+		return user
+	}
+	return storage.GetUserByName(idOrUsername)
+}
+
+func usersList() {
+	for _, user := range storage.List() {
+		daysUntilRefresh := tokenRefreshAfterHours/24 - int(time.Since(user.Updated).Hours()/24)
+		fmt.Printf("%s\t%s\tupdated=%s\trefresh_in=%dd\n", user.ID, user.Username, user.Updated.Format(time.RFC3339), daysUntilRefresh)
+	}
+}
+
+func usersShow(idOrUsername string) {
+	user := resolveUser(idOrUsername)
+	if user == nil {
+		fmt.Println("User not found")
+		os.Exit(1)
+	}
+	fmt.Printf("id=%s\nusername=%s\nupdated=%s\n", user.ID, user.Username, user.Updated.Format(time.RFC3339))
+}
+
+func usersDelete(idOrUsername string) {
+	user := resolveUser(idOrUsername)
+	if user == nil {
+		fmt.Println("User not found")
+		os.Exit(1)
+	}
+	storage.DeleteUser(user.ID, user.Username)
+	fmt.Printf("Deleted %s (%s)\n", user.Username, user.ID)
+}
+
+func usersRefresh(idOrUsername string) {
+	user := resolveUser(idOrUsername)
+	if user == nil {
+		fmt.Println("User not found")
+		os.Exit(1)
+	}
+	result, success := traktSrv.AuthRequest("", user.Username, "", user.RefreshToken, "refresh_token")
+	if !success {
+		fmt.Println("Refresh failed")
+		os.Exit(1)
+	}
+	user.UpdateUser(result["access_token"].(string), result["refresh_token"].(string))
+	fmt.Printf("Refreshed %s (%s)\n", user.Username, user.ID)
+}
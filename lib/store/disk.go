@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiskStore persists users as one JSON file per user under DATA_DIR
+// (./keystore by default). It's the zero-config default for local/dev use.
+// Blobs (see GetBlob/SetBlob) are not persisted to disk; they're kept in an
+// in-memory cache that's lost on restart, which is an acceptable tradeoff
+// for a cache.
+type DiskStore struct {
+	dir     string
+	blobs   map[string]diskBlob
+	blobsMu sync.Mutex
+	jobsMu  sync.Mutex
+}
+
+type diskBlob struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewDiskStore creates a DiskStore, creating its backing directory if needed.
+func NewDiskStore() *DiskStore {
+	dir := os.Getenv("DATA_DIR")
+	if dir == "" {
+		dir = "keystore"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatal("Unable to create disk store directory: ", err)
+	}
+	return &DiskStore{dir: dir, blobs: map[string]diskBlob{}}
+}
+
+func (s *DiskStore) path(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+func (s *DiskStore) GetUser(id string) *User {
+	data, err := ioutil.ReadFile(s.path(id))
+	if err != nil {
+		return nil
+	}
+	var user User
+	if err := json.Unmarshal(data, &user); err != nil {
+		log.Print("Failed to unmarshal user from disk: ", err)
+		return nil
+	}
+	user.store = s
+	return &user
+}
+
+func (s *DiskStore) GetUserByName(username string) *User {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		user := s.GetUser(f.Name())
+		if user != nil && strings.EqualFold(user.Username, username) {
+			return user
+		}
+	}
+	return nil
+}
+
+func (s *DiskStore) WriteUser(user User) {
+	user.store = nil
+	data, err := json.Marshal(user)
+	if err != nil {
+		log.Print("Failed to marshal user: ", err)
+		return
+	}
+	if err := ioutil.WriteFile(s.path(user.ID), data, 0644); err != nil {
+		log.Print("Failed to write user to disk: ", err)
+	}
+}
+
+func (s *DiskStore) DeleteUser(id, username string) {
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		log.Print("Failed to delete user from disk: ", err)
+	}
+}
+
+func (s *DiskStore) List() []User {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil
+	}
+	var users []User
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if user := s.GetUser(f.Name()); user != nil {
+			users = append(users, *user)
+		}
+	}
+	return users
+}
+
+func (s *DiskStore) Ping(ctx context.Context) error {
+	_, err := os.Stat(s.dir)
+	return err
+}
+
+func (s *DiskStore) jobsDir(sub string) string {
+	dir := filepath.Join(s.dir, "jobs", sub)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Print("Failed to create scrobble jobs directory: ", err)
+	}
+	return dir
+}
+
+func (s *DiskStore) EnqueueJob(job ScrobbleJob) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		log.Print("Failed to marshal scrobble job: ", err)
+		return
+	}
+	path := filepath.Join(s.jobsDir("pending"), job.ID+".json")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		log.Print("Failed to write scrobble job to disk: ", err)
+	}
+}
+
+func (s *DiskStore) DequeueJob() (*ScrobbleJob, bool) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+
+	dir := s.jobsDir("pending")
+	files, err := ioutil.ReadDir(dir)
+	if err != nil || len(files) == 0 {
+		return nil, false
+	}
+
+	oldest := files[0]
+	for _, f := range files[1:] {
+		if f.ModTime().Before(oldest.ModTime()) {
+			oldest = f
+		}
+	}
+
+	path := filepath.Join(dir, oldest.Name())
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	if err := os.Remove(path); err != nil {
+		log.Print("Failed to remove dequeued scrobble job: ", err)
+	}
+
+	var job ScrobbleJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		log.Print("Failed to unmarshal scrobble job: ", err)
+		return nil, false
+	}
+	return &job, true
+}
+
+func (s *DiskStore) DeadLetterJob(job ScrobbleJob) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		log.Print("Failed to marshal dead-letter scrobble job: ", err)
+		return
+	}
+	path := filepath.Join(s.jobsDir("dead"), job.ID+".json")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		log.Print("Failed to write dead-letter scrobble job: ", err)
+	}
+}
+
+func (s *DiskStore) ListDeadLetterJobs() []ScrobbleJob {
+	dir := s.jobsDir("dead")
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return []ScrobbleJob{}
+	}
+
+	jobs := []ScrobbleJob{}
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			continue
+		}
+		var job ScrobbleJob
+		if err := json.Unmarshal(data, &job); err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func (s *DiskStore) GetBlob(key string) ([]byte, bool) {
+	s.blobsMu.Lock()
+	defer s.blobsMu.Unlock()
+
+	blob, ok := s.blobs[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(blob.expires) {
+		delete(s.blobs, key)
+		return nil, false
+	}
+	return blob.value, true
+}
+
+func (s *DiskStore) SetBlob(key string, value []byte, ttl time.Duration) {
+	s.blobsMu.Lock()
+	defer s.blobsMu.Unlock()
+	s.blobs[key] = diskBlob{value: value, expires: time.Now().Add(ttl)}
+}
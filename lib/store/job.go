@@ -0,0 +1,15 @@
+package store
+
+import "time"
+
+// ScrobbleJob is one Plex webhook payload awaiting an async scrobble attempt
+// against Trakt. Persisting it means a Trakt outage or a Plex-triggered
+// burst of webhooks doesn't drop playback events on the floor.
+type ScrobbleJob struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	PlexPayload []byte    `json:"plex_payload"`
+	ReceivedAt  time.Time `json:"received_at"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+}
@@ -0,0 +1,242 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// PostgresqlStore persists users in a "users" table.
+type PostgresqlStore struct {
+	db *sql.DB
+}
+
+// NewPostgresqlClient opens a connection pool to the given PostgreSQL URL
+// and ensures the users table exists.
+func NewPostgresqlClient(url string) *sql.DB {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		log.Fatal("Unable to connect to postgresql: ", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		username TEXT UNIQUE NOT NULL,
+		access_token TEXT NOT NULL,
+		refresh_token TEXT NOT NULL,
+		updated TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		log.Fatal("Unable to create users table: ", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS blobs (
+		key TEXT PRIMARY KEY,
+		value BYTEA NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		log.Fatal("Unable to create blobs table: ", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS scrobble_jobs (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		plex_payload BYTEA NOT NULL,
+		received_at TIMESTAMP NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		last_error TEXT,
+		status TEXT NOT NULL DEFAULT 'pending'
+	)`)
+	if err != nil {
+		log.Fatal("Unable to create scrobble_jobs table: ", err)
+	}
+	return db
+}
+
+// NewPostgresqlStore wraps an already-configured *sql.DB.
+func NewPostgresqlStore(db *sql.DB) *PostgresqlStore {
+	return &PostgresqlStore{db: db}
+}
+
+func (s *PostgresqlStore) scanUser(row *sql.Row) *User {
+	var user User
+	if err := row.Scan(&user.ID, &user.Username, &user.AccessToken, &user.RefreshToken, &user.Updated); err != nil {
+		if err != sql.ErrNoRows {
+			log.Print("Failed to scan user: ", err)
+		}
+		return nil
+	}
+	user.store = s
+	return &user
+}
+
+func (s *PostgresqlStore) GetUser(id string) *User {
+	row := s.db.QueryRow(`SELECT id, username, access_token, refresh_token, updated FROM users WHERE id = $1`, id)
+	return s.scanUser(row)
+}
+
+func (s *PostgresqlStore) GetUserByName(username string) *User {
+	row := s.db.QueryRow(`SELECT id, username, access_token, refresh_token, updated FROM users WHERE username = $1`, username)
+	return s.scanUser(row)
+}
+
+func (s *PostgresqlStore) WriteUser(user User) {
+	_, err := s.db.Exec(`INSERT INTO users (id, username, access_token, refresh_token, updated)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET username = $2, access_token = $3, refresh_token = $4, updated = $5`,
+		user.ID, user.Username, user.AccessToken, user.RefreshToken, user.Updated)
+	if err != nil {
+		log.Print("Failed to write user to postgresql: ", err)
+	}
+}
+
+func (s *PostgresqlStore) DeleteUser(id, username string) {
+	if _, err := s.db.Exec(`DELETE FROM users WHERE id = $1`, id); err != nil {
+		log.Print("Failed to delete user from postgresql: ", err)
+	}
+}
+
+func (s *PostgresqlStore) List() []User {
+	rows, err := s.db.Query(`SELECT id, username, access_token, refresh_token, updated FROM users`)
+	if err != nil {
+		log.Print("Failed to list users from postgresql: ", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Username, &user.AccessToken, &user.RefreshToken, &user.Updated); err != nil {
+			log.Print("Failed to scan user: ", err)
+			continue
+		}
+		user.store = s
+		users = append(users, user)
+	}
+	return users
+}
+
+func (s *PostgresqlStore) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return s.db.PingContext(ctx)
+}
+
+func (s *PostgresqlStore) EnqueueJob(job ScrobbleJob) {
+	_, err := s.db.Exec(`INSERT INTO scrobble_jobs (id, user_id, plex_payload, received_at, attempts, last_error, status)
+		VALUES ($1, $2, $3, $4, $5, $6, 'pending')
+		ON CONFLICT (id) DO UPDATE SET attempts = $5, last_error = $6, status = 'pending'`,
+		job.ID, job.UserID, job.PlexPayload, job.ReceivedAt, job.Attempts, nullable(job.LastError))
+	if err != nil {
+		log.Print("Failed to enqueue scrobble job in postgresql: ", err)
+	}
+}
+
+func (s *PostgresqlStore) DequeueJob() (*ScrobbleJob, bool) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		log.Print("Failed to begin transaction to dequeue scrobble job: ", err)
+		return nil, false
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`SELECT id, user_id, plex_payload, received_at, attempts, last_error FROM scrobble_jobs
+		WHERE status = 'pending' ORDER BY received_at LIMIT 1 FOR UPDATE SKIP LOCKED`)
+
+	job, err := scanJob(row)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Print("Failed to dequeue scrobble job from postgresql: ", err)
+		}
+		return nil, false
+	}
+
+	if _, err := tx.Exec(`DELETE FROM scrobble_jobs WHERE id = $1`, job.ID); err != nil {
+		log.Print("Failed to remove dequeued scrobble job from postgresql: ", err)
+		return nil, false
+	}
+	if err := tx.Commit(); err != nil {
+		log.Print("Failed to commit dequeued scrobble job transaction: ", err)
+		return nil, false
+	}
+	return job, true
+}
+
+func (s *PostgresqlStore) DeadLetterJob(job ScrobbleJob) {
+	_, err := s.db.Exec(`INSERT INTO scrobble_jobs (id, user_id, plex_payload, received_at, attempts, last_error, status)
+		VALUES ($1, $2, $3, $4, $5, $6, 'dead')
+		ON CONFLICT (id) DO UPDATE SET attempts = $5, last_error = $6, status = 'dead'`,
+		job.ID, job.UserID, job.PlexPayload, job.ReceivedAt, job.Attempts, nullable(job.LastError))
+	if err != nil {
+		log.Print("Failed to dead-letter scrobble job in postgresql: ", err)
+	}
+}
+
+func (s *PostgresqlStore) ListDeadLetterJobs() []ScrobbleJob {
+	rows, err := s.db.Query(`SELECT id, user_id, plex_payload, received_at, attempts, last_error FROM scrobble_jobs WHERE status = 'dead'`)
+	if err != nil {
+		log.Print("Failed to list dead-letter scrobble jobs from postgresql: ", err)
+		return []ScrobbleJob{}
+	}
+	defer rows.Close()
+
+	jobs := []ScrobbleJob{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			log.Print("Failed to scan scrobble job: ", err)
+			continue
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// row is satisfied by both *sql.Row and *sql.Rows.
+type row interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(r row) (*ScrobbleJob, error) {
+	var job ScrobbleJob
+	var lastError sql.NullString
+	if err := r.Scan(&job.ID, &job.UserID, &job.PlexPayload, &job.ReceivedAt, &job.Attempts, &lastError); err != nil {
+		return nil, err
+	}
+	job.LastError = lastError.String
+	return &job, nil
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func (s *PostgresqlStore) GetBlob(key string) ([]byte, bool) {
+	var value []byte
+	var expiresAt time.Time
+	row := s.db.QueryRow(`SELECT value, expires_at FROM blobs WHERE key = $1`, key)
+	if err := row.Scan(&value, &expiresAt); err != nil {
+		if err != sql.ErrNoRows {
+			log.Print("Failed to read blob from postgresql: ", err)
+		}
+		return nil, false
+	}
+	if time.Now().After(expiresAt) {
+		return nil, false
+	}
+	return value, true
+}
+
+func (s *PostgresqlStore) SetBlob(key string, value []byte, ttl time.Duration) {
+	_, err := s.db.Exec(`INSERT INTO blobs (key, value, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = $2, expires_at = $3`,
+		key, value, time.Now().Add(ttl))
+	if err != nil {
+		log.Print("Failed to write blob to postgresql: ", err)
+	}
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore persists users as JSON-encoded string values under user:<id>,
+// with a secondary username:<name> -> id key for lookups by Plex username.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisClientWithUrl builds a redis.Client from a redis:// connection URL.
+func NewRedisClientWithUrl(rawURL string) *redis.Client {
+	opt, err := redis.ParseURL(rawURL)
+	if err != nil {
+		log.Fatal("Unable to parse REDIS_URL: ", err)
+	}
+	return redis.NewClient(opt)
+}
+
+// NewRedisClient builds a redis.Client from a host:port address and password.
+func NewRedisClient(addr, password string) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+	})
+}
+
+// NewRedisStore wraps an already-configured redis.Client.
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) GetUser(id string) *User {
+	data, err := s.client.Get(context.Background(), "user:"+id).Bytes()
+	if err != nil {
+		return nil
+	}
+	var user User
+	if err := json.Unmarshal(data, &user); err != nil {
+		log.Print("Failed to unmarshal user from redis: ", err)
+		return nil
+	}
+	user.store = s
+	return &user
+}
+
+func (s *RedisStore) GetUserByName(username string) *User {
+	id, err := s.client.Get(context.Background(), "username:"+username).Result()
+	if err != nil {
+		return nil
+	}
+	return s.GetUser(id)
+}
+
+func (s *RedisStore) WriteUser(user User) {
+	ctx := context.Background()
+	user.store = nil
+	data, err := json.Marshal(user)
+	if err != nil {
+		log.Print("Failed to marshal user: ", err)
+		return
+	}
+	if err := s.client.Set(ctx, "user:"+user.ID, data, 0).Err(); err != nil {
+		log.Print("Failed to write user to redis: ", err)
+		return
+	}
+	if err := s.client.Set(ctx, "username:"+user.Username, user.ID, 0).Err(); err != nil {
+		log.Print("Failed to write username index to redis: ", err)
+	}
+}
+
+func (s *RedisStore) DeleteUser(id, username string) {
+	ctx := context.Background()
+	s.client.Del(ctx, "user:"+id)
+	s.client.Del(ctx, "username:"+username)
+}
+
+func (s *RedisStore) List() []User {
+	ctx := context.Background()
+	var users []User
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, "user:*", 100).Result()
+		if err != nil {
+			log.Print("Failed to scan users in redis: ", err)
+			return users
+		}
+		for _, key := range keys {
+			data, err := s.client.Get(ctx, key).Bytes()
+			if err != nil {
+				continue
+			}
+			var user User
+			if err := json.Unmarshal(data, &user); err != nil {
+				continue
+			}
+			user.store = s
+			users = append(users, user)
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	return users
+}
+
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+const (
+	scrobbleJobsKey     = "scrobble_jobs"
+	scrobbleJobsDeadKey = "scrobble_jobs:dead"
+)
+
+func (s *RedisStore) EnqueueJob(job ScrobbleJob) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		log.Print("Failed to marshal scrobble job: ", err)
+		return
+	}
+	if err := s.client.RPush(context.Background(), scrobbleJobsKey, data).Err(); err != nil {
+		log.Print("Failed to enqueue scrobble job in redis: ", err)
+	}
+}
+
+func (s *RedisStore) DequeueJob() (*ScrobbleJob, bool) {
+	data, err := s.client.LPop(context.Background(), scrobbleJobsKey).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	var job ScrobbleJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		log.Print("Failed to unmarshal scrobble job: ", err)
+		return nil, false
+	}
+	return &job, true
+}
+
+func (s *RedisStore) DeadLetterJob(job ScrobbleJob) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		log.Print("Failed to marshal dead-letter scrobble job: ", err)
+		return
+	}
+	if err := s.client.RPush(context.Background(), scrobbleJobsDeadKey, data).Err(); err != nil {
+		log.Print("Failed to dead-letter scrobble job in redis: ", err)
+	}
+}
+
+func (s *RedisStore) ListDeadLetterJobs() []ScrobbleJob {
+	items, err := s.client.LRange(context.Background(), scrobbleJobsDeadKey, 0, -1).Result()
+	if err != nil {
+		return []ScrobbleJob{}
+	}
+
+	jobs := []ScrobbleJob{}
+	for _, item := range items {
+		var job ScrobbleJob
+		if err := json.Unmarshal([]byte(item), &job); err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func (s *RedisStore) GetBlob(key string) ([]byte, bool) {
+	value, err := s.client.Get(context.Background(), "blob:"+key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (s *RedisStore) SetBlob(key string, value []byte, ttl time.Duration) {
+	if err := s.client.Set(context.Background(), "blob:"+key, value, ttl).Err(); err != nil {
+		log.Print("Failed to write blob to redis: ", err)
+	}
+}
@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Store is the persistence backend for users. Implementations exist for
+// disk, Redis and PostgreSQL; main.go picks one based on the environment.
+type Store interface {
+	GetUser(id string) *User
+	GetUserByName(username string) *User
+	WriteUser(user User)
+	DeleteUser(id, username string)
+	// List returns every user in the store, for the `goplaxt users` CLI.
+	List() []User
+	Ping(ctx context.Context) error
+
+	// EnqueueJob and DequeueJob back a persistent FIFO queue of scrobble
+	// jobs. DequeueJob reports ok == false when the queue is empty.
+	EnqueueJob(job ScrobbleJob)
+	DequeueJob() (job *ScrobbleJob, ok bool)
+	// DeadLetterJob records a job that exhausted its retries or hit a
+	// terminal error, for inspection via GET /jobs/failed.
+	DeadLetterJob(job ScrobbleJob)
+	ListDeadLetterJobs() []ScrobbleJob
+
+	// GetBlob and SetBlob back a generic, TTL'd byte-blob cache that
+	// callers (e.g. lib/trakt) use to avoid refetching slow-changing
+	// upstream data. A missing or expired key reports ok == false.
+	GetBlob(key string) (value []byte, ok bool)
+	SetBlob(key string, value []byte, ttl time.Duration)
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User represents a single Plex -> Trakt authorization.
+type User struct {
+	ID           string
+	Username     string
+	AccessToken  string
+	RefreshToken string
+	Updated      time.Time
+	store        Store
+}
+
+// NewUser creates and persists a new User for the given Plex username.
+func NewUser(username, accessToken, refreshToken string, storage Store) User {
+	user := User{
+		ID:           uuid.New().String(),
+		Username:     username,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		Updated:      time.Now(),
+		store:        storage,
+	}
+	storage.WriteUser(user)
+	return user
+}
+
+// UpdateUser refreshes the stored tokens for the user and persists them.
+func (u *User) UpdateUser(accessToken, refreshToken string) {
+	u.AccessToken = accessToken
+	u.RefreshToken = refreshToken
+	u.Updated = time.Now()
+	u.store.WriteUser(*u)
+}
@@ -0,0 +1,47 @@
+package trakt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TTLs for the two upstream lookups findEpisode/findMovie make. Show search
+// results and episode lists change rarely, so caching them avoids redundant
+// Trakt calls for every episode of a binge.
+const (
+	showCacheTTL    = 24 * time.Hour
+	episodeCacheTTL = 12 * time.Hour
+)
+
+// cachedGet serves url from t.store's blob cache when present, otherwise
+// fetches it and populates the cache under key for ttl.
+func (t *Trakt) cachedGet(ctx context.Context, key string, ttl time.Duration, url string) ([]byte, error) {
+	if body, ok := t.store.GetBlob(key); ok {
+		return body, nil
+	}
+
+	body, status, err := t.client.get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTraktUnavailable, err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: request returned status %d", ErrTraktUnavailable, status)
+	}
+	t.store.SetBlob(key, body, ttl)
+	return body, nil
+}
+
+func showSearchCacheKey(tvdbID string) string {
+	return fmt.Sprintf("trakt:search:show:tvdb:%s", tvdbID)
+}
+
+func seasonsCacheKey(traktID int) string {
+	return fmt.Sprintf("trakt:seasons:%d", traktID)
+}
+
+func movieSearchCacheKey(title string, year int) string {
+	return fmt.Sprintf("trakt:search:movie:%s:%d", title, year)
+}
@@ -0,0 +1,84 @@
+package trakt
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	requestTimeout = 5 * time.Second
+	maxRetries     = 3
+)
+
+// traktClient is a context-aware HTTP client for read-only Trakt API calls.
+// It retries 5xx and 429 responses up to maxRetries times with exponential
+// backoff, honoring a Retry-After header when present.
+type traktClient struct {
+	id     string
+	client *http.Client
+}
+
+func newTraktClient(id string) *traktClient {
+	return &traktClient{id: id, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// get returns the response body along with its HTTP status code, so callers
+// can distinguish a genuine 200 from a non-retryable error response (e.g. a
+// 4xx from bad credentials) before deciding whether the body is cacheable.
+func (c *traktClient) get(ctx context.Context, url string) ([]byte, int, error) {
+	var lastErr error
+	retryAfter := ""
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("trakt-api-version", "2")
+		req.Header.Add("trakt-api-key", c.id)
+
+		resp, err := c.client.Do(req)
+		if err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			return body, resp.StatusCode, err
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("trakt request returned status %d", resp.StatusCode)
+			retryAfter = resp.Header.Get("Retry-After")
+			resp.Body.Close()
+		}
+
+		if attempt == maxRetries {
+			break
+		}
+
+		delay := retryAfterDelay(retryAfter, attempt)
+		log.Printf("Trakt request failed (%s), retrying in %s: %s", lastErr, delay, url)
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return nil, 0, lastErr
+}
+
+// retryAfterDelay prefers the server-supplied Retry-After (seconds) and
+// falls back to an exponential backoff keyed off the attempt number.
+func retryAfterDelay(retryAfter string, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(retryAfter); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return time.Duration(1<<uint(attempt)) * time.Second
+}
@@ -0,0 +1,170 @@
+package trakt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/xanderstrike/goplaxt/lib/store"
+)
+
+// DeviceCodeResponse is the response to POST /oauth/device/code.
+type DeviceCodeResponse struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURL string `json:"verification_url"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// DeviceStatus reports where a device-code authorization currently stands.
+type DeviceStatus struct {
+	State string `json:"state"` // "pending", "authorized", "expired", "denied", "error"
+	Error string `json:"error,omitempty"`
+	URL   string `json:"url,omitempty"` // /api?id=... once authorized
+}
+
+var (
+	// ErrDeviceCodeExpired is reported in DeviceStatus.Error when the polling
+	// deadline passes before the user completes authorization.
+	ErrDeviceCodeExpired = errors.New("device code expired before authorization")
+	// ErrDeviceCodeDenied is reported in DeviceStatus.Error when Trakt rejects
+	// the device code (not found, already used, or denied by the user).
+	ErrDeviceCodeDenied = errors.New("device code was denied or is no longer valid")
+)
+
+// DeviceCode requests a new device code from Trakt, to be shown to the user
+// as a short code plus a verification URL.
+func (t *Trakt) DeviceCode() (*DeviceCodeResponse, error) {
+	values := map[string]string{"client_id": t.id}
+	jsonValue, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.authClient.Post("https://api.trakt.tv/oauth/device/code", "application/json", bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("device code request returned status %d", resp.StatusCode)
+	}
+
+	var dc DeviceCodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&dc); err != nil {
+		return nil, err
+	}
+
+	t.setDeviceStatus(dc.DeviceCode, DeviceStatus{State: "pending"})
+	return &dc, nil
+}
+
+// PollDeviceToken polls /oauth/device/token at the given interval until the
+// user authorizes, the code is denied, or expiresIn seconds pass. On success
+// it stores a new user via store.NewUser and records the resulting status so
+// GetDeviceStatus can report it back to the waiting client.
+func (t *Trakt) PollDeviceToken(ctx context.Context, username, deviceCode string, interval, expiresIn int) {
+	deadline := time.Now().Add(time.Duration(expiresIn) * time.Second)
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Now().After(deadline) {
+				t.setDeviceStatus(deviceCode, DeviceStatus{State: "expired", Error: ErrDeviceCodeExpired.Error()})
+				return
+			}
+
+			result, status, err := t.deviceToken(deviceCode)
+			switch status {
+			case http.StatusOK:
+				accessToken, _ := result["access_token"].(string)
+				refreshToken, _ := result["refresh_token"].(string)
+				user := store.NewUser(username, accessToken, refreshToken, t.store)
+				t.setDeviceStatus(deviceCode, DeviceStatus{State: "authorized", URL: fmt.Sprintf("/api?id=%s", user.ID)})
+				return
+			case http.StatusBadRequest:
+				// Authorization pending, keep polling.
+				continue
+			case http.StatusNotFound, http.StatusConflict, http.StatusGone:
+				t.setDeviceStatus(deviceCode, DeviceStatus{State: "denied", Error: ErrDeviceCodeDenied.Error()})
+				return
+			default:
+				if err != nil {
+					log.Print("Device token poll failed: ", err)
+				}
+				t.setDeviceStatus(deviceCode, DeviceStatus{State: "error", Error: fmt.Sprintf("unexpected status %d", status)})
+				return
+			}
+		}
+	}
+}
+
+func (t *Trakt) deviceToken(deviceCode string) (map[string]interface{}, int, error) {
+	values := map[string]string{
+		"code":          deviceCode,
+		"client_id":     t.id,
+		"client_secret": t.secret,
+	}
+	jsonValue, err := json.Marshal(values)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := t.authClient.Post("https://api.trakt.tv/oauth/device/token", "application/json", bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, nil
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return result, resp.StatusCode, nil
+}
+
+// GetDeviceStatus reports the current state of a previously requested device
+// code, for the /device/status long-poll endpoint.
+func (t *Trakt) GetDeviceStatus(deviceCode string) (DeviceStatus, bool) {
+	t.deviceMu.Lock()
+	defer t.deviceMu.Unlock()
+	status, ok := t.device[deviceCode]
+	return status, ok
+}
+
+// deviceStatusRetention is how long a terminal device status (anything but
+// "pending") stays available to GetDeviceStatus before it's pruned, long
+// enough for a client's last poll to observe it.
+const deviceStatusRetention = 5 * time.Minute
+
+func (t *Trakt) setDeviceStatus(deviceCode string, status DeviceStatus) {
+	t.deviceMu.Lock()
+	defer t.deviceMu.Unlock()
+	if t.device == nil {
+		t.device = map[string]DeviceStatus{}
+	}
+	t.device[deviceCode] = status
+
+	if status.State != "pending" {
+		time.AfterFunc(deviceStatusRetention, func() {
+			t.deviceMu.Lock()
+			defer t.deviceMu.Unlock()
+			delete(t.device, deviceCode)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package trakt
+
+import "errors"
+
+// ErrNoTvdbMatch means the Plex item (its tvdb guid for episodes, its title
+// and year for movies) didn't resolve to a matching show, season, episode,
+// or movie on Trakt. It's terminal: retrying the
+// same job won't produce a different result.
+var ErrNoTvdbMatch = errors.New("no matching title found on trakt")
+
+// ErrTraktUnavailable wraps a failed request or non-2xx response from the
+// Trakt API. It's retryable, so the worker re-enqueues the job with backoff.
+var ErrTraktUnavailable = errors.New("trakt api unavailable")
@@ -0,0 +1,56 @@
+package trakt
+
+// ShowInfo is a single result from a Trakt tvdb show search.
+type ShowInfo struct {
+	Show struct {
+		Title string `json:"title"`
+		Year  int    `json:"year"`
+		Ids   struct {
+			Trakt int    `json:"trakt"`
+			Slug  string `json:"slug"`
+			Tvdb  int    `json:"tvdb"`
+		} `json:"ids"`
+	} `json:"show"`
+}
+
+// Season is a single season entry from /shows/{id}/seasons?extended=episodes.
+type Season struct {
+	Number   int       `json:"number"`
+	Episodes []Episode `json:"episodes"`
+}
+
+// Episode identifies a single episode for scrobbling.
+type Episode struct {
+	Season int    `json:"season"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
+
+// Movie identifies a single movie for scrobbling.
+type Movie struct {
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+	Ids   struct {
+		Trakt int    `json:"trakt"`
+		Slug  string `json:"slug"`
+		Imdb  string `json:"imdb"`
+		Tmdb  int    `json:"tmdb"`
+	} `json:"ids"`
+}
+
+// MovieSearchResult is a single result from a Trakt movie search.
+type MovieSearchResult struct {
+	Movie Movie `json:"movie"`
+}
+
+// ShowScrobbleBody is the payload for POST /scrobble/{action} for episodes.
+type ShowScrobbleBody struct {
+	Progress int     `json:"progress"`
+	Episode  Episode `json:"episode"`
+}
+
+// MovieScrobbleBody is the payload for POST /scrobble/{action} for movies.
+type MovieScrobbleBody struct {
+	Progress int   `json:"progress"`
+	Movie    Movie `json:"movie"`
+}
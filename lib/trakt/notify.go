@@ -0,0 +1,255 @@
+package trakt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xanderstrike/plexhooks"
+)
+
+const fanartCacheTTL = 24 * time.Hour
+
+// ScrobbleEvent is the JSON payload posted to NOTIFY_WEBHOOKS after a
+// successful scrobble.
+type ScrobbleEvent struct {
+	User          string `json:"user"`
+	Event         string `json:"event"`
+	Action        string `json:"action"`
+	Progress      int    `json:"progress"`
+	Title         string `json:"title"`
+	Year          int    `json:"year"`
+	Season        int    `json:"season,omitempty"`
+	Episode       int    `json:"episode,omitempty"`
+	PosterURL     string `json:"poster_url,omitempty"`
+	BackgroundURL string `json:"background_url,omitempty"`
+	TraktURL      string `json:"trakt_url"`
+}
+
+func (t *Trakt) notifyShow(ctx context.Context, pr plexhooks.PlexResponse, action string, progress int, show *ShowInfo, episode *Episode) {
+	poster, background := t.showArtwork(ctx, show.Show.Ids.Tvdb)
+	t.notify(ctx, ScrobbleEvent{
+		User:          strings.ToLower(pr.Account.Title),
+		Event:         pr.Event,
+		Action:        action,
+		Progress:      progress,
+		Title:         show.Show.Title,
+		Year:          show.Show.Year,
+		Season:        episode.Season,
+		Episode:       episode.Number,
+		PosterURL:     poster,
+		BackgroundURL: background,
+		TraktURL:      fmt.Sprintf("https://trakt.tv/shows/%s", show.Show.Ids.Slug),
+	})
+}
+
+func (t *Trakt) notifyMovie(ctx context.Context, pr plexhooks.PlexResponse, action string, progress int, movie *Movie) {
+	poster, background := t.movieArtwork(ctx, movie.Ids.Tmdb)
+	t.notify(ctx, ScrobbleEvent{
+		User:          strings.ToLower(pr.Account.Title),
+		Event:         pr.Event,
+		Action:        action,
+		Progress:      progress,
+		Title:         movie.Title,
+		Year:          movie.Year,
+		PosterURL:     poster,
+		BackgroundURL: background,
+		TraktURL:      fmt.Sprintf("https://trakt.tv/movies/%s", movie.Ids.Slug),
+	})
+}
+
+func (t *Trakt) notify(ctx context.Context, event ScrobbleEvent) {
+	webhooks := splitWebhooks(os.Getenv("NOTIFY_WEBHOOKS"))
+	discordWebhook := os.Getenv("NOTIFY_DISCORD_WEBHOOK")
+	if len(webhooks) == 0 && discordWebhook == "" {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Print("Failed to marshal notify event: ", err)
+		return
+	}
+
+	for _, hook := range webhooks {
+		t.postWebhook(ctx, hook, body)
+	}
+
+	if discordWebhook != "" {
+		t.postDiscordWebhook(ctx, discordWebhook, event)
+	}
+}
+
+func splitWebhooks(raw string) []string {
+	var hooks []string
+	for _, hook := range strings.Split(raw, ",") {
+		hook = strings.TrimSpace(hook)
+		if hook != "" {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+func (t *Trakt) postWebhook(ctx context.Context, url string, body []byte) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		log.Print("Failed to build notify webhook request: ", err)
+		return
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := t.authClient.Do(req)
+	if err != nil {
+		log.Print("Notify webhook request failed: ", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Notify webhook returned status %d", resp.StatusCode)
+	}
+}
+
+// discordImage is Discord's embed thumbnail/image shape: {"url": "..."}.
+type discordImage struct {
+	URL string `json:"url,omitempty"`
+}
+
+// discordEmbedField is a single embed in a Discord webhook payload.
+// Thumbnail/Image are pointers so a missing artwork URL (e.g. no
+// FANART_API_KEY) omits the field entirely rather than sending an empty
+// "url":"" object — omitempty has no effect on non-pointer struct fields.
+type discordEmbedField struct {
+	Title       string        `json:"title"`
+	URL         string        `json:"url,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Thumbnail   *discordImage `json:"thumbnail,omitempty"`
+	Image       *discordImage `json:"image,omitempty"`
+}
+
+// discordEmbed is the subset of Discord's webhook embed format we need.
+type discordEmbed struct {
+	Embeds []discordEmbedField `json:"embeds"`
+}
+
+func (t *Trakt) postDiscordWebhook(ctx context.Context, url string, event ScrobbleEvent) {
+	title := event.Title
+	if event.Season > 0 || event.Episode > 0 {
+		title = fmt.Sprintf("%s S%02dE%02d", event.Title, event.Season, event.Episode)
+	} else {
+		title = fmt.Sprintf("%s (%d)", event.Title, event.Year)
+	}
+
+	field := discordEmbedField{
+		Title:       title,
+		URL:         event.TraktURL,
+		Description: fmt.Sprintf("%s scrobbled by %s (%d%%)", event.Action, event.User, event.Progress),
+	}
+	if event.PosterURL != "" {
+		field.Thumbnail = &discordImage{URL: event.PosterURL}
+	}
+	if event.BackgroundURL != "" {
+		field.Image = &discordImage{URL: event.BackgroundURL}
+	}
+
+	payload := discordEmbed{Embeds: []discordEmbedField{field}}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Print("Failed to marshal discord embed: ", err)
+		return
+	}
+	t.postWebhook(ctx, url, body)
+}
+
+// showArtwork looks up the poster/backdrop for a show on Fanart.tv by tvdb
+// id. It's a no-op (returning empty URLs) when FANART_API_KEY isn't set.
+func (t *Trakt) showArtwork(ctx context.Context, tvdbID int) (poster, background string) {
+	return t.fanartArtwork(ctx, fmt.Sprintf("https://webservice.fanart.tv/v3/tv/%d", tvdbID), "tvposter", "showbackground")
+}
+
+// movieArtwork looks up the poster/backdrop for a movie on Fanart.tv by tmdb id.
+func (t *Trakt) movieArtwork(ctx context.Context, tmdbID int) (poster, background string) {
+	return t.fanartArtwork(ctx, fmt.Sprintf("https://webservice.fanart.tv/v3/movies/%d", tmdbID), "movieposter", "moviebackground")
+}
+
+func (t *Trakt) fanartArtwork(ctx context.Context, url, posterKey, backgroundKey string) (poster, background string) {
+	apiKey := os.Getenv("FANART_API_KEY")
+	if apiKey == "" {
+		return "", ""
+	}
+
+	cacheKey := "fanart:" + url
+	body, ok := t.store.GetBlob(cacheKey)
+	if !ok {
+		req, err := http.NewRequestWithContext(ctx, "GET", url+"?api_key="+apiKey, nil)
+		if err != nil {
+			log.Print("Failed to build fanart request: ", err)
+			return "", ""
+		}
+
+		resp, err := t.authClient.Do(req)
+		if err != nil {
+			log.Print("Fanart request failed: ", err)
+			return "", ""
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", ""
+		}
+
+		buf := new(bytes.Buffer)
+		if _, err := buf.ReadFrom(resp.Body); err != nil {
+			log.Print("Failed to read fanart response: ", err)
+			return "", ""
+		}
+		body = buf.Bytes()
+		t.store.SetBlob(cacheKey, body, fanartCacheTTL)
+	}
+
+	var art map[string]json.RawMessage
+	if err := json.Unmarshal(body, &art); err != nil {
+		return "", ""
+	}
+
+	return mostLikedImage(art[posterKey]), mostLikedImage(art[backgroundKey])
+}
+
+type fanartImage struct {
+	URL   string `json:"url"`
+	Likes string `json:"likes"`
+}
+
+func mostLikedImage(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var images []fanartImage
+	if err := json.Unmarshal(raw, &images); err != nil {
+		return ""
+	}
+
+	var best fanartImage
+	bestLikes := -1
+	for _, img := range images {
+		likes, err := strconv.Atoi(img.Likes)
+		if err != nil {
+			continue
+		}
+		if likes > bestLikes {
+			bestLikes = likes
+			best = img
+		}
+	}
+	return best.URL
+}
@@ -0,0 +1,116 @@
+package trakt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/xanderstrike/goplaxt/lib/store"
+	"github.com/xanderstrike/plexhooks"
+)
+
+const (
+	defaultScrobbleWorkers = 2
+	maxScrobbleAttempts    = 5
+	emptyQueuePollInterval = time.Second
+)
+
+// StartWorkers launches the scrobble job workers and blocks until ctx is
+// done. The worker count is controlled by SCROBBLE_WORKERS (default 2).
+func (t *Trakt) StartWorkers(ctx context.Context) {
+	workers := defaultScrobbleWorkers
+	if v := os.Getenv("SCROBBLE_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			workers = n
+		}
+	}
+
+	done := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		go func() {
+			t.runWorker(ctx)
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < workers; i++ {
+		<-done
+	}
+}
+
+func (t *Trakt) runWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		job, ok := t.store.DequeueJob()
+		if !ok {
+			time.Sleep(emptyQueuePollInterval)
+			continue
+		}
+
+		if err := t.runJob(ctx, *job); err != nil {
+			t.retryOrDeadLetter(*job, err)
+		}
+	}
+}
+
+// runJob calls processJob behind a recover(), so a bug that slips past
+// findEpisode/findMovie's own guards (an unexpected panic rather than a
+// typed error) fails only the offending job instead of taking the whole
+// worker pool down with it. The panic is reported as a retryable
+// ErrTraktUnavailable, so the job is retried with backoff and dead-lettered
+// once it exhausts maxScrobbleAttempts.
+func (t *Trakt) runJob(ctx context.Context, job store.ScrobbleJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: panic: %v", ErrTraktUnavailable, r)
+		}
+	}()
+	return t.processJob(ctx, job)
+}
+
+// processJob replays a queued job's Plex payload through Handle on behalf
+// of its owning user.
+func (t *Trakt) processJob(ctx context.Context, job store.ScrobbleJob) error {
+	user := t.store.GetUser(job.UserID)
+	if user == nil {
+		log.Printf("Dropping scrobble job %s: user %s no longer exists", job.ID, job.UserID)
+		return nil
+	}
+
+	var pr plexhooks.PlexResponse
+	if err := json.Unmarshal(job.PlexPayload, &pr); err != nil {
+		log.Printf("Dropping scrobble job %s: failed to unmarshal payload: %v", job.ID, err)
+		return nil
+	}
+
+	return t.Handle(ctx, pr, *user)
+}
+
+// retryOrDeadLetter re-enqueues job after an exponential backoff, unless err
+// is terminal (ErrNoTvdbMatch) or the job has exhausted maxScrobbleAttempts,
+// in which case it's moved to the dead-letter queue for GET /jobs/failed.
+func (t *Trakt) retryOrDeadLetter(job store.ScrobbleJob, err error) {
+	job.Attempts++
+	job.LastError = err.Error()
+
+	if errors.Is(err, ErrNoTvdbMatch) || job.Attempts >= maxScrobbleAttempts {
+		log.Printf("Dead-lettering scrobble job %s after %d attempts: %v", job.ID, job.Attempts, err)
+		t.store.DeadLetterJob(job)
+		return
+	}
+
+	backoff := time.Duration(1<<uint(job.Attempts-1)) * time.Second
+	log.Printf("Retrying scrobble job %s in %s (attempt %d): %v", job.ID, backoff, job.Attempts, err)
+	time.AfterFunc(backoff, func() {
+		t.store.EnqueueJob(job)
+	})
+}
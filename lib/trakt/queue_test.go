@@ -0,0 +1,114 @@
+package trakt
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xanderstrike/goplaxt/lib/store"
+)
+
+// fakeStore is a minimal in-memory store.Store used to exercise code paths
+// that need a Store without pulling in a real backend.
+type fakeStore struct {
+	mu         sync.Mutex
+	blobs      map[string][]byte
+	enqueued   []store.ScrobbleJob
+	deadLetter []store.ScrobbleJob
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{blobs: map[string][]byte{}}
+}
+
+func (s *fakeStore) GetUser(id string) *store.User             { return nil }
+func (s *fakeStore) GetUserByName(username string) *store.User { return nil }
+func (s *fakeStore) WriteUser(user store.User)                 {}
+func (s *fakeStore) DeleteUser(id, username string)            {}
+func (s *fakeStore) List() []store.User                        { return nil }
+func (s *fakeStore) Ping(ctx context.Context) error            { return nil }
+
+func (s *fakeStore) EnqueueJob(job store.ScrobbleJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enqueued = append(s.enqueued, job)
+}
+
+func (s *fakeStore) DequeueJob() (*store.ScrobbleJob, bool) { return nil, false }
+
+func (s *fakeStore) DeadLetterJob(job store.ScrobbleJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetter = append(s.deadLetter, job)
+}
+
+func (s *fakeStore) ListDeadLetterJobs() []store.ScrobbleJob { return s.deadLetter }
+
+func (s *fakeStore) GetBlob(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.blobs[key]
+	return v, ok
+}
+
+func (s *fakeStore) SetBlob(key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = value
+}
+
+func (s *fakeStore) enqueuedCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.enqueued)
+}
+
+func (s *fakeStore) deadLetterCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.deadLetter)
+}
+
+func TestRetryOrDeadLetter(t *testing.T) {
+	cases := []struct {
+		name           string
+		err            error
+		startAttempts  int
+		wantDeadLetter bool
+	}{
+		{"terminal error dead-letters immediately", ErrNoTvdbMatch, 0, true},
+		{"retryable error exhausting attempts dead-letters", ErrTraktUnavailable, maxScrobbleAttempts - 1, true},
+		{"retryable error with attempts left is re-enqueued", ErrTraktUnavailable, 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := newFakeStore()
+			tr := &Trakt{store: fs}
+			job := store.ScrobbleJob{ID: "job-1", Attempts: tc.startAttempts}
+
+			tr.retryOrDeadLetter(job, tc.err)
+
+			if tc.wantDeadLetter {
+				if fs.deadLetterCount() != 1 {
+					t.Fatalf("expected job to be dead-lettered, deadLetterCount=%d enqueuedCount=%d", fs.deadLetterCount(), fs.enqueuedCount())
+				}
+				return
+			}
+
+			// The retry path schedules EnqueueJob via time.AfterFunc after an
+			// exponential backoff; with startAttempts=0 that's 1s.
+			deadline := time.Now().Add(2 * time.Second)
+			for fs.enqueuedCount() == 0 && time.Now().Before(deadline) {
+				time.Sleep(50 * time.Millisecond)
+			}
+			if fs.enqueuedCount() != 1 {
+				t.Fatalf("expected job to be re-enqueued, enqueuedCount=%d deadLetterCount=%d", fs.enqueuedCount(), fs.deadLetterCount())
+			}
+			if fs.deadLetterCount() != 0 {
+				t.Fatalf("retryable job should not be dead-lettered, deadLetterCount=%d", fs.deadLetterCount())
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package trakt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+
+	"github.com/xanderstrike/goplaxt/lib/store"
+	"github.com/xanderstrike/plexhooks"
+)
+
+// Trakt holds the OAuth application credentials and the user store, and
+// exposes everything needed to authorize Plex users and scrobble their
+// playback to Trakt.
+type Trakt struct {
+	id         string
+	secret     string
+	store      store.Store
+	client     *traktClient
+	authClient *http.Client
+	playback   map[string]int
+	playbackMu sync.Mutex
+	device     map[string]DeviceStatus
+	deviceMu   sync.Mutex
+}
+
+// New creates a Trakt service bound to the given OAuth application and store.
+func New(id, secret string, storage store.Store) *Trakt {
+	return &Trakt{
+		id:         id,
+		secret:     secret,
+		store:      storage,
+		client:     newTraktClient(id),
+		authClient: &http.Client{Timeout: requestTimeout},
+		playback:   map[string]int{},
+	}
+}
+
+// AuthRequest exchanges a Plex authorization for a Trakt token, via either
+// the authorization_code grant (code set) or the refresh_token grant
+// (refreshToken set). redirect_uri is only sent for the authorization_code
+// grant, which is the one Trakt actually validates it against; the
+// refresh_token grant omits it, so callers with no request-derived selfRoot
+// (e.g. the users CLI) can refresh safely. It returns the decoded token
+// response and whether the request succeeded.
+func (t *Trakt) AuthRequest(selfRoot, username, code, refreshToken, grantType string) (map[string]interface{}, bool) {
+	values := map[string]string{
+		"code":          code,
+		"refresh_token": refreshToken,
+		"client_id":     t.id,
+		"client_secret": t.secret,
+		"grant_type":    grantType,
+	}
+	if grantType == "authorization_code" {
+		values["redirect_uri"] = fmt.Sprintf("%s/authorize?username=%s", selfRoot, username)
+	}
+	jsonValue, err := json.Marshal(values)
+	if err != nil {
+		log.Print("Failed to marshal auth request: ", err)
+		return nil, false
+	}
+
+	resp, err := t.authClient.Post("https://api.trakt.tv/oauth/token", "application/json", bytes.NewBuffer(jsonValue))
+	if err != nil {
+		log.Print("Auth request failed: ", err)
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Auth request returned status %d", resp.StatusCode)
+		return nil, false
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		log.Print("Failed to decode auth response: ", err)
+		return nil, false
+	}
+
+	return result, true
+}
+
+// Handle dispatches a Plex webhook event to the appropriate scrobble
+// handler. Errors are typed (ErrNoTvdbMatch, ErrTraktUnavailable) so a
+// caller running Handle off a job queue can tell a terminal failure from a
+// retryable one.
+func (t *Trakt) Handle(ctx context.Context, pr plexhooks.PlexResponse, user store.User) error {
+	var err error
+	switch pr.Metadata.LibrarySectionType {
+	case "show":
+		err = t.HandleShow(ctx, pr, user.AccessToken)
+	case "movie":
+		err = t.HandleMovie(ctx, pr, user.AccessToken)
+	}
+	if err != nil {
+		return err
+	}
+	log.Print("Event logged")
+	return nil
+}
+
+// SavePlaybackProgress remembers the last reported playback percentage for a
+// given Plex client/item pair, as reported by the /:/timeline endpoint.
+func (t *Trakt) SavePlaybackProgress(clientUuid, ratingKey, state string, percent int) {
+	t.playbackMu.Lock()
+	defer t.playbackMu.Unlock()
+	t.playback[clientUuid+":"+ratingKey] = percent
+}
+
+func (t *Trakt) HandleShow(ctx context.Context, pr plexhooks.PlexResponse, accessToken string) error {
+	event, progress := getAction(pr)
+
+	episode, show, err := t.findEpisode(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	scrobbleObject := ShowScrobbleBody{
+		Progress: progress,
+		Episode:  *episode,
+	}
+
+	scrobbleJSON, err := json.Marshal(scrobbleObject)
+	if err != nil {
+		return err
+	}
+
+	if err := t.scrobbleRequest(event, scrobbleJSON, accessToken); err != nil {
+		return err
+	}
+
+	t.notifyShow(ctx, pr, event, progress, show, episode)
+	return nil
+}
+
+func (t *Trakt) HandleMovie(ctx context.Context, pr plexhooks.PlexResponse, accessToken string) error {
+	event, progress := getAction(pr)
+
+	movie, err := t.findMovie(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	scrobbleObject := MovieScrobbleBody{
+		Progress: progress,
+		Movie:    *movie,
+	}
+
+	scrobbleJSON, err := json.Marshal(scrobbleObject)
+	if err != nil {
+		return err
+	}
+
+	if err := t.scrobbleRequest(event, scrobbleJSON, accessToken); err != nil {
+		return err
+	}
+
+	t.notifyMovie(ctx, pr, event, progress, movie)
+	return nil
+}
+
+func (t *Trakt) findEpisode(ctx context.Context, pr plexhooks.PlexResponse) (*Episode, *ShowInfo, error) {
+	re := regexp.MustCompile(`thetvdb://(\d*)/(\d*)/(\d*)`)
+	showID := re.FindStringSubmatch(pr.Metadata.Guid)
+	if showID == nil {
+		return nil, nil, fmt.Errorf("%w: guid %q is not a tvdb guid", ErrNoTvdbMatch, pr.Metadata.Guid)
+	}
+
+	log.Print(fmt.Sprintf("Finding show for %s %s %s", showID[1], showID[2], showID[3]))
+
+	url := fmt.Sprintf("https://api.trakt.tv/search/tvdb/%s?type=show", showID[1])
+
+	respBody, err := t.cachedGet(ctx, showSearchCacheKey(showID[1]), showCacheTTL, url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var showInfo []ShowInfo
+	if err := json.Unmarshal(respBody, &showInfo); err != nil {
+		return nil, nil, err
+	}
+	if len(showInfo) == 0 {
+		return nil, nil, fmt.Errorf("%w: no show found for tvdb id %s", ErrNoTvdbMatch, showID[1])
+	}
+
+	show := showInfo[0]
+	url = fmt.Sprintf("https://api.trakt.tv/shows/%d/seasons?extended=episodes", show.Show.Ids.Trakt)
+
+	respBody, err = t.cachedGet(ctx, seasonsCacheKey(show.Show.Ids.Trakt), episodeCacheTTL, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	var seasons []Season
+	if err := json.Unmarshal(respBody, &seasons); err != nil {
+		return nil, nil, err
+	}
+
+	for _, season := range seasons {
+		if fmt.Sprintf("%d", season.Number) == showID[2] {
+			for _, episode := range season.Episodes {
+				if fmt.Sprintf("%d", episode.Number) == showID[3] {
+					return &episode, &show, nil
+				}
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: no episode %s matched season %s of show %s", ErrNoTvdbMatch, showID[3], showID[2], showID[1])
+}
+
+func (t *Trakt) findMovie(ctx context.Context, pr plexhooks.PlexResponse) (*Movie, error) {
+	log.Print(fmt.Sprintf("Finding movie for %s (%d)", pr.Metadata.Title, pr.Metadata.Year))
+	url := fmt.Sprintf("https://api.trakt.tv/search/movie?query=%s", url.PathEscape(pr.Metadata.Title))
+
+	respBody, err := t.cachedGet(ctx, movieSearchCacheKey(pr.Metadata.Title, pr.Metadata.Year), showCacheTTL, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []MovieSearchResult
+	if err := json.Unmarshal(respBody, &results); err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Movie.Year == pr.Metadata.Year {
+			return &result.Movie, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no movie matched %s (%d)", ErrNoTvdbMatch, pr.Metadata.Title, pr.Metadata.Year)
+}
+
+func (t *Trakt) scrobbleRequest(action string, body []byte, accessToken string) error {
+	u := fmt.Sprintf("https://api.trakt.tv/scrobble/%s", action)
+
+	req, err := http.NewRequest("POST", u, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to build scrobble request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	req.Header.Add("trakt-api-version", "2")
+	req.Header.Add("trakt-api-key", t.id)
+
+	resp, err := t.authClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTraktUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: scrobble request returned status %d", ErrTraktUnavailable, resp.StatusCode)
+	}
+	return nil
+}
+
+func getAction(pr plexhooks.PlexResponse) (string, int) {
+	switch pr.Event {
+	case "media.play":
+		return "start", 0
+	case "media.pause":
+		return "stop", 0
+	case "media.resume":
+		return "start", 0
+	case "media.stop":
+		return "stop", 0
+	case "media.scrobble":
+		return "stop", 90
+	}
+	return "", 0
+}
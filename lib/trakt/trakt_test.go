@@ -0,0 +1,74 @@
+package trakt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xanderstrike/plexhooks"
+)
+
+func TestFindEpisode_ErrorPaths(t *testing.T) {
+	cases := []struct {
+		name  string
+		guid  string
+		blobs map[string][]byte
+	}{
+		{
+			name: "guid from a non-tvdb agent does not match the tvdb pattern",
+			guid: "plex://episode/5d9c08234de0ee001fc53c57",
+		},
+		{
+			name:  "tvdb guid with no matching show",
+			guid:  "thetvdb://123/1/2",
+			blobs: map[string][]byte{showSearchCacheKey("123"): []byte(`[]`)},
+		},
+		{
+			name: "tvdb guid with no matching episode",
+			guid: "thetvdb://123/1/2",
+			blobs: map[string][]byte{
+				showSearchCacheKey("123"): []byte(`[{"show":{"title":"Show","year":2020,"ids":{"trakt":1,"slug":"show","tvdb":123}}}]`),
+				seasonsCacheKey(1):        []byte(`[{"number":1,"episodes":[{"season":1,"number":9,"title":"Other"}]}]`),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := newFakeStore()
+			for key, value := range tc.blobs {
+				fs.blobs[key] = value
+			}
+			tr := &Trakt{store: fs}
+			pr := plexhooks.PlexResponse{}
+			pr.Metadata.Guid = tc.guid
+
+			episode, show, err := tr.findEpisode(context.Background(), pr)
+
+			if episode != nil || show != nil {
+				t.Fatalf("expected no match, got episode=%v show=%v", episode, show)
+			}
+			if !errors.Is(err, ErrNoTvdbMatch) {
+				t.Fatalf("expected ErrNoTvdbMatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFindMovie_NoMatch(t *testing.T) {
+	fs := newFakeStore()
+	fs.blobs[movieSearchCacheKey("Some Movie", 2020)] = []byte(`[{"movie":{"title":"Some Movie","year":2019,"ids":{"trakt":1,"slug":"some-movie"}}}]`)
+	tr := &Trakt{store: fs}
+	pr := plexhooks.PlexResponse{}
+	pr.Metadata.Title = "Some Movie"
+	pr.Metadata.Year = 2020
+
+	movie, err := tr.findMovie(context.Background(), pr)
+
+	if movie != nil {
+		t.Fatalf("expected no match, got %v", movie)
+	}
+	if !errors.Is(err, ErrNoTvdbMatch) {
+		t.Fatalf("expected ErrNoTvdbMatch, got %v", err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/etherlabsio/healthcheck"
+	"github.com/google/uuid"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/xanderstrike/goplaxt/lib/store"
@@ -78,6 +79,41 @@ func authorize(w http.ResponseWriter, r *http.Request) {
 	tmpl.Execute(w, data)
 }
 
+func deviceStart(w http.ResponseWriter, r *http.Request) {
+	username := strings.ToLower(r.URL.Query().Get("username"))
+	if username == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	dc, err := traktSrv.DeviceCode()
+	if err != nil {
+		log.Print("Failed to get device code: ", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	go traktSrv.PollDeviceToken(context.Background(), username, dc.DeviceCode, dc.Interval, dc.ExpiresIn)
+
+	json.NewEncoder(w).Encode(dc)
+}
+
+func deviceStatus(w http.ResponseWriter, r *http.Request) {
+	deviceCode := r.URL.Query().Get("device_code")
+	if deviceCode == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	status, ok := traktSrv.GetDeviceStatus(deviceCode)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
+
 func api(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
 	if id == "" {
@@ -117,7 +153,7 @@ func api(w http.ResponseWriter, r *http.Request) {
 	}
 
 	tokenAge := time.Since(user.Updated).Hours()
-	if tokenAge > 1440 { // tokens expire after 3 months, so we refresh after 2
+	if tokenAge > tokenRefreshAfterHours { // tokens expire after 3 months, so we refresh after 2
 		log.Println("User access token outdated, refreshing...")
 		result, success := traktSrv.AuthRequest(SelfRoot(r), user.Username, "", user.RefreshToken, "refresh_token")
 		if success {
@@ -133,15 +169,43 @@ func api(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if username == user.Username {
-		// FIXME - make everything take the pointer
-		traktSrv.Handle(re, *user)
+		job := store.ScrobbleJob{
+			ID:          uuid.New().String(),
+			UserID:      user.ID,
+			PlexPayload: []byte(match[0]),
+			ReceivedAt:  time.Now(),
+		}
+		storage.EnqueueJob(job)
 	} else {
 		log.Println(fmt.Sprintf("Plex username %s does not equal %s, skipping", strings.ToLower(re.Account.Title), user.Username))
 	}
 
+	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode("success")
 }
 
+// jobsFailed returns dead-lettered scrobble jobs. With no id query param it
+// returns every dead-lettered job; with ?id=<job id> it returns just that
+// one (404 if it isn't dead-lettered).
+func jobsFailed(w http.ResponseWriter, r *http.Request) {
+	jobs := storage.ListDeadLetterJobs()
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		json.NewEncoder(w).Encode(jobs)
+		return
+	}
+
+	for _, job := range jobs {
+		if job.ID == id {
+			json.NewEncoder(w).Encode(job)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode("job not found")
+}
+
 func timeline(w http.ResponseWriter, r *http.Request) {
 	clientUuid := r.Header.Get("X-Plex-Client-Identifier")
 	ratingKey := r.URL.Query().Get("ratingKey")
@@ -198,8 +262,7 @@ func healthcheckHandler() http.Handler {
 	)
 }
 
-func main() {
-	log.Printf("Started version=\"%s (%s@%s)\"", version, commit, date)
+func initStorage() {
 	if os.Getenv("POSTGRESQL_URL") != "" {
 		storage = store.NewPostgresqlStore(store.NewPostgresqlClient(os.Getenv("POSTGRESQL_URL")))
 		log.Println("Using postgresql storage:", os.Getenv("POSTGRESQL_URL"))
@@ -214,6 +277,18 @@ func main() {
 		log.Println("Using disk storage:")
 	}
 	traktSrv = trakt.New(os.Getenv("TRAKT_ID"), os.Getenv("TRAKT_SECRET"), storage)
+}
+
+func main() {
+	log.Printf("Started version=\"%s (%s@%s)\"", version, commit, date)
+	initStorage()
+
+	if len(os.Args) > 1 && os.Args[1] == "users" {
+		runUsersCommand(os.Args[2:])
+		return
+	}
+
+	go traktSrv.StartWorkers(context.Background())
 
 	router := mux.NewRouter()
 	// Assumption: Behind a proper web server (nginx/traefik, etc) that removes/replaces trusted headers
@@ -228,7 +303,10 @@ func main() {
 		router.Use(allowedHostsHandler(os.Getenv("ALLOWED_HOSTNAMES")))
 	}
 	router.HandleFunc("/authorize", authorize).Methods("GET")
+	router.HandleFunc("/device/start", deviceStart).Methods("POST")
+	router.HandleFunc("/device/status", deviceStatus).Methods("GET")
 	router.HandleFunc("/api", api).Methods("POST")
+	router.HandleFunc("/jobs/failed", jobsFailed).Methods("GET")
 	router.HandleFunc("/:/timeline", timeline).Methods("GET", "POST")
 	router.Handle("/healthcheck", healthcheckHandler()).Methods("GET")
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {